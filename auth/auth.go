@@ -0,0 +1,55 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+// Package auth provides token acquisition and storage for clients that
+// authenticate against the Cloud-Library Service using OAuth2, rather than a
+// long-lived static token.
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrReauthRequired is returned by a TokenSource when the caller must go
+// through an interactive authentication flow again, for example because no
+// refresh token is available or the refresh token has been revoked, but the
+// TokenSource is configured not to prompt interactively. See
+// DeviceFlow.NonInteractive.
+var ErrReauthRequired = errors.New("auth: re-authentication required")
+
+// Token represents an OAuth2 access token, and (if available) the refresh
+// token and expiry needed to obtain a new one.
+type Token struct {
+	// AccessToken is the token to present to the service.
+	AccessToken string
+	// RefreshToken, if non-empty, can be exchanged for a new AccessToken
+	// once the current one expires.
+	RefreshToken string
+	// TokenType is the token type returned by the authorization server,
+	// typically "Bearer".
+	TokenType string
+	// Expiry is the time at which AccessToken expires. The zero value
+	// indicates the token does not expire.
+	Expiry time.Time
+}
+
+// expired reports whether t is expired, or will expire within skew.
+func (t Token) expired(skew time.Duration) bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return time.Now().Add(skew).After(t.Expiry)
+}
+
+// TokenSource supplies an access token for use in the Authorization header
+// of each request made by a Client. Implementations are responsible for
+// obtaining and, where possible, transparently refreshing the token.
+type TokenSource interface {
+	// Token returns a valid access token, refreshing or re-authenticating
+	// as necessary.
+	Token(ctx context.Context) (string, error)
+}
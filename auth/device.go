@@ -0,0 +1,367 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAccessDenied is returned when the user declines the authorization
+// request.
+var ErrAccessDenied = errors.New("auth: access denied")
+
+// ErrDeviceCodeExpired is returned when the device code expires before the
+// user completes authorization.
+var ErrDeviceCodeExpired = errors.New("auth: device code expired")
+
+const (
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
+	grantTypeRefreshToken = "refresh_token"
+
+	defaultDeviceCodePath = "oauth/device/code"
+	defaultTokenPath      = "oauth/token"
+
+	// refreshSkew is how far ahead of expiry Token will proactively
+	// refresh an access token.
+	refreshSkew = 30 * time.Second
+)
+
+// DeviceFlow implements TokenSource using the OAuth2 device authorization
+// grant (RFC 8628). On first use it prompts the caller to authorize the
+// device via Prompter, then polls the authorization server until a token is
+// issued. The resulting token is persisted via Store and refreshed
+// automatically as it nears expiry on subsequent calls.
+type DeviceFlow struct {
+	// BaseURL of the authorization server. DeviceCodePath and TokenPath
+	// are resolved relative to it.
+	BaseURL string
+	// ClientID identifies this client to the authorization server.
+	ClientID string
+	// Audience, if non-empty, is sent as the "audience" parameter.
+	Audience string
+	// Scope, if non-empty, is sent as the "scope" parameter.
+	Scope string
+	// DeviceCodePath overrides the default "oauth/device/code".
+	DeviceCodePath string
+	// TokenPath overrides the default "oauth/token".
+	TokenPath string
+	// Prompter displays the user code and verification URL. Defaults to
+	// DefaultPrompter.
+	Prompter Prompter
+	// OpenBrowser, if true, attempts to open VerificationURIComplete in
+	// the user's default browser in addition to calling Prompter.
+	OpenBrowser bool
+	// Store persists the obtained token between calls, keyed by BaseURL.
+	// If nil, the token is kept in memory only for the lifetime of the
+	// DeviceFlow value.
+	Store SecretsStore
+	// HTTPClient is used to make requests to the authorization server.
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// NonInteractive, if true, causes Token to return ErrReauthRequired
+	// instead of prompting the user, whenever no cached token can be used
+	// or refreshed. Set this for callers (eg. a background service) that
+	// cannot service an interactive device authorization prompt.
+	NonInteractive bool
+
+	// sem serializes Token, so that concurrent callers (as from a
+	// Client's newRequest) share one in-flight refresh or authorization
+	// instead of each independently starting their own. It is a size-1
+	// semaphore rather than a sync.Mutex because a full device
+	// authorization can block for minutes (the user-code expiry window),
+	// and a plain Mutex.Lock cannot be interrupted by a caller's ctx
+	// while waiting.
+	semOnce sync.Once
+	sem     chan struct{}
+	cached  *Token
+}
+
+// lock acquires d's semaphore, returning ctx.Err() if ctx is done first.
+func (d *DeviceFlow) lock(ctx context.Context) error {
+	d.semOnce.Do(func() { d.sem = make(chan struct{}, 1) })
+	select {
+	case d.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *DeviceFlow) unlock() {
+	<-d.sem
+}
+
+// Token implements TokenSource. It returns a cached token if one is valid,
+// refreshing or performing a full device authorization flow as needed.
+// Concurrent calls are serialized, so only one refresh or authorization is
+// ever in flight at a time; a caller whose ctx is done before it is their
+// turn gets ctx.Err() rather than blocking for the full device-flow
+// duration.
+func (d *DeviceFlow) Token(ctx context.Context) (string, error) {
+	if err := d.lock(ctx); err != nil {
+		return "", err
+	}
+	defer d.unlock()
+
+	tok, err := d.currentToken()
+	if err == nil && !tok.expired(refreshSkew) {
+		return tok.AccessToken, nil
+	}
+
+	if err == nil && tok.RefreshToken != "" {
+		refreshed, rerr := d.refresh(ctx, tok.RefreshToken)
+		if rerr == nil {
+			d.saveToken(refreshed)
+			return refreshed.AccessToken, nil
+		}
+	}
+
+	if d.NonInteractive {
+		return "", ErrReauthRequired
+	}
+
+	authorized, err := d.authorize(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	d.saveToken(authorized)
+	return authorized.AccessToken, nil
+}
+
+func (d *DeviceFlow) currentToken() (Token, error) {
+	if d.cached != nil {
+		return *d.cached, nil
+	}
+	if d.Store == nil {
+		return Token{}, ErrNoToken
+	}
+	return d.Store.Get(d.BaseURL)
+}
+
+func (d *DeviceFlow) saveToken(tok Token) {
+	d.cached = &tok
+	if d.Store != nil {
+		// Best-effort: a failure to persist shouldn't prevent the
+		// caller from using the token it already has.
+		_ = d.Store.Set(d.BaseURL, tok)
+	}
+}
+
+func (d *DeviceFlow) httpClient() *http.Client {
+	if d.HTTPClient != nil {
+		return d.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (d *DeviceFlow) prompter() Prompter {
+	if d.Prompter != nil {
+		return d.Prompter
+	}
+	return DefaultPrompter
+}
+
+func (d *DeviceFlow) endpoint(path, defaultPath string) (string, error) {
+	if path == "" {
+		path = defaultPath
+	}
+
+	base := d.BaseURL
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	return u.ResolveReference(&url.URL{Path: path}).String(), nil
+}
+
+// deviceCodeResponse is the response body of a successful request to the
+// device authorization endpoint.
+type deviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// tokenResponse is the response body of a request to the token endpoint,
+// whether successful or an error per RFC 6749 section 5.2.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// authorize runs the full device authorization grant: it requests a device
+// code, prompts the user, then polls for a token.
+func (d *DeviceFlow) authorize(ctx context.Context) (Token, error) {
+	endpoint, err := d.endpoint(d.DeviceCodePath, defaultDeviceCodePath)
+	if err != nil {
+		return Token{}, err
+	}
+
+	form := url.Values{"client_id": {d.ClientID}}
+	if d.Audience != "" {
+		form.Set("audience", d.Audience)
+	}
+	if d.Scope != "" {
+		form.Set("scope", d.Scope)
+	}
+
+	var dc deviceCodeResponse
+	if err := d.postForm(ctx, endpoint, form, &dc); err != nil {
+		return Token{}, fmt.Errorf("error requesting device code: %w", err)
+	}
+
+	da := DeviceAuth{
+		DeviceCode:              dc.DeviceCode,
+		UserCode:                dc.UserCode,
+		VerificationURI:         dc.VerificationURI,
+		VerificationURIComplete: dc.VerificationURIComplete,
+		ExpiresIn:               dc.ExpiresIn,
+		Interval:                dc.Interval,
+	}
+
+	if err := d.prompter().Prompt(da); err != nil {
+		return Token{}, err
+	}
+
+	if d.OpenBrowser && da.VerificationURIComplete != "" {
+		_ = OpenBrowser(da.VerificationURIComplete)
+	}
+
+	return d.poll(ctx, dc)
+}
+
+// poll repeatedly exchanges the device code for a token until the user
+// completes authorization, the device code expires, or access is denied.
+func (d *DeviceFlow) poll(ctx context.Context, dc deviceCodeResponse) (Token, error) {
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	deadline := time.Now().Add(time.Duration(dc.ExpiresIn) * time.Second)
+
+	endpoint, err := d.endpoint(d.TokenPath, defaultTokenPath)
+	if err != nil {
+		return Token{}, err
+	}
+
+	for {
+		if time.Now().After(deadline) {
+			return Token{}, ErrDeviceCodeExpired
+		}
+
+		select {
+		case <-ctx.Done():
+			return Token{}, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		form := url.Values{
+			"grant_type":  {grantTypeDeviceCode},
+			"device_code": {dc.DeviceCode},
+			"client_id":   {d.ClientID},
+		}
+
+		var tr tokenResponse
+		err := d.postForm(ctx, endpoint, form, &tr)
+		if err == nil {
+			return tokenFromResponse(tr), nil
+		}
+
+		switch tr.Error {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		case "expired_token":
+			return Token{}, ErrDeviceCodeExpired
+		case "access_denied":
+			return Token{}, ErrAccessDenied
+		default:
+			return Token{}, err
+		}
+	}
+}
+
+// refresh exchanges a refresh token for a new access token.
+func (d *DeviceFlow) refresh(ctx context.Context, refreshToken string) (Token, error) {
+	endpoint, err := d.endpoint(d.TokenPath, defaultTokenPath)
+	if err != nil {
+		return Token{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {grantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+		"client_id":     {d.ClientID},
+	}
+
+	var tr tokenResponse
+	if err := d.postForm(ctx, endpoint, form, &tr); err != nil {
+		return Token{}, fmt.Errorf("error refreshing token: %w", err)
+	}
+	return tokenFromResponse(tr), nil
+}
+
+// postForm POSTs form to endpoint and decodes the JSON response into out.
+// If the response status code does not indicate success, out is still
+// populated (so the caller can inspect the "error" field per RFC 6749), and
+// an error describing the HTTP status is returned.
+func (d *DeviceFlow) postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := d.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected http status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func tokenFromResponse(tr tokenResponse) Token {
+	tok := Token{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		TokenType:    tr.TokenType,
+	}
+	if tr.ExpiresIn > 0 {
+		tok.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newPollServer returns a test token endpoint that replies with the bodies
+// in responses in order, one per request received, and a DeviceFlow
+// pointed at it.
+func newPollServer(t *testing.T, responses []tokenResponse) (*DeviceFlow, *httptest.Server) {
+	t.Helper()
+
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n >= len(responses) {
+			t.Fatalf("unexpected request %d, only %d responses configured", n+1, len(responses))
+		}
+		tr := responses[n]
+		n++
+
+		if tr.Error != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_ = json.NewEncoder(w).Encode(tr)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &DeviceFlow{BaseURL: srv.URL, HTTPClient: srv.Client()}, srv
+}
+
+func TestDeviceFlowPollSuccess(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "authorization_pending"},
+		{AccessToken: "tok", TokenType: "Bearer"},
+	})
+
+	tok, err := d.poll(context.Background(), deviceCodeResponse{
+		DeviceCode: "dc", Interval: 1, ExpiresIn: 30,
+	})
+	if err != nil {
+		t.Fatalf("poll() = %v", err)
+	}
+	if tok.AccessToken != "tok" {
+		t.Errorf("AccessToken = %q, want %q", tok.AccessToken, "tok")
+	}
+}
+
+func TestDeviceFlowPollExpiredToken(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "expired_token"},
+	})
+
+	_, err := d.poll(context.Background(), deviceCodeResponse{
+		DeviceCode: "dc", Interval: 1, ExpiresIn: 30,
+	})
+	if err != ErrDeviceCodeExpired {
+		t.Fatalf("poll() err = %v, want %v", err, ErrDeviceCodeExpired)
+	}
+}
+
+func TestDeviceFlowPollAccessDenied(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "access_denied"},
+	})
+
+	_, err := d.poll(context.Background(), deviceCodeResponse{
+		DeviceCode: "dc", Interval: 1, ExpiresIn: 30,
+	})
+	if err != ErrAccessDenied {
+		t.Fatalf("poll() err = %v, want %v", err, ErrAccessDenied)
+	}
+}
+
+func TestDeviceFlowPollDeadlineElapsed(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "authorization_pending"},
+	})
+
+	// ExpiresIn has already elapsed by the time poll checks it, so it
+	// should report expiry before waiting or making any request.
+	_, err := d.poll(context.Background(), deviceCodeResponse{
+		DeviceCode: "dc", Interval: 1, ExpiresIn: -1,
+	})
+	if err != ErrDeviceCodeExpired {
+		t.Fatalf("poll() err = %v, want %v", err, ErrDeviceCodeExpired)
+	}
+}
+
+func TestDeviceFlowPollContextCanceled(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "authorization_pending"},
+		{Error: "authorization_pending"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := d.poll(ctx, deviceCodeResponse{
+		DeviceCode: "dc", Interval: 5, ExpiresIn: 30,
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("poll() err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDeviceFlowTokenCtxAwareWaitingForLock(t *testing.T) {
+	d, _ := newPollServer(t, []tokenResponse{
+		{Error: "authorization_pending"},
+	})
+
+	// Hold the flow's serialization semaphore as if a slow authorize
+	// were already in flight.
+	background := context.Background()
+	if err := d.lock(background); err != nil {
+		t.Fatalf("lock() = %v", err)
+	}
+	defer d.unlock()
+
+	ctx, cancel := context.WithTimeout(background, 20*time.Millisecond)
+	defer cancel()
+
+	_, err := d.Token(ctx)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Token() err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
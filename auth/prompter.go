@@ -0,0 +1,63 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package auth
+
+import (
+	"fmt"
+	"os"
+)
+
+// DeviceAuth describes the information a user needs to complete a device
+// authorization grant, as returned by the authorization server's
+// /oauth/device/code endpoint.
+type DeviceAuth struct {
+	// DeviceCode identifies the device session when polling for a token.
+	DeviceCode string
+	// UserCode is the short code the user enters at VerificationURI, if
+	// VerificationURIComplete is not used.
+	UserCode string
+	// VerificationURI is the URL the user should visit to authorize the
+	// device.
+	VerificationURI string
+	// VerificationURIComplete is VerificationURI with UserCode already
+	// embedded, so the user does not need to type it in manually.
+	VerificationURIComplete string
+	// ExpiresIn is the lifetime, in seconds, of DeviceCode and UserCode.
+	ExpiresIn int
+	// Interval is the minimum number of seconds the client must wait
+	// between polling requests.
+	Interval int
+}
+
+// Prompter displays device authorization instructions to the user.
+// Implementations may additionally open a browser to VerificationURIComplete.
+type Prompter interface {
+	Prompt(da DeviceAuth) error
+}
+
+// PrompterFunc adapts a function to a Prompter.
+type PrompterFunc func(da DeviceAuth) error
+
+// Prompt calls f(da).
+func (f PrompterFunc) Prompt(da DeviceAuth) error { return f(da) }
+
+// stderrPrompter is the default Prompter, which writes instructions to
+// os.Stderr without attempting to open a browser.
+type stderrPrompter struct{}
+
+// Prompt writes the user code and verification URL to os.Stderr.
+func (stderrPrompter) Prompt(da DeviceAuth) error {
+	if da.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "To authenticate, visit:\n\n    %s\n\n", da.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(os.Stderr, "To authenticate, visit:\n\n    %s\n\nand enter code: %s\n\n", da.VerificationURI, da.UserCode)
+	}
+	return nil
+}
+
+// DefaultPrompter is the Prompter used when a DeviceFlow is not configured
+// with one explicitly.
+var DefaultPrompter Prompter = stderrPrompter{}
@@ -0,0 +1,107 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoToken is returned by a SecretsStore when no token has been stored for
+// the given key.
+var ErrNoToken = errors.New("auth: no token stored")
+
+// SecretsStore persists a Token between process invocations, keyed by an
+// opaque name (typically the service's base URL).
+type SecretsStore interface {
+	// Get returns the token stored under key, or ErrNoToken if none exists.
+	Get(key string) (Token, error)
+	// Set stores tok under key, creating or overwriting any existing entry.
+	Set(key string, tok Token) error
+}
+
+// fileSecretsStore is the default SecretsStore. It persists tokens, keyed by
+// name, in the single JSON file at path.
+type fileSecretsStore struct {
+	path string
+}
+
+// NewFileSecretsStore returns a SecretsStore backed by a JSON file under
+// $XDG_CONFIG_HOME/sylabs (or $HOME/.config/sylabs if XDG_CONFIG_HOME is
+// unset). The file and its parent directory are created on first Set, with
+// permissions restricted to the current user.
+func NewFileSecretsStore() (SecretsStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return &fileSecretsStore{path: filepath.Join(dir, "remote-config.json")}, nil
+}
+
+func configDir() (string, error) {
+	if d := os.Getenv("XDG_CONFIG_HOME"); d != "" {
+		return filepath.Join(d, "sylabs"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "sylabs"), nil
+}
+
+func (s *fileSecretsStore) load() (map[string]Token, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Token{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := map[string]Token{}
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", s.path, err)
+	}
+	return tokens, nil
+}
+
+// Get implements SecretsStore.
+func (s *fileSecretsStore) Get(key string) (Token, error) {
+	tokens, err := s.load()
+	if err != nil {
+		return Token{}, err
+	}
+
+	tok, ok := tokens[key]
+	if !ok {
+		return Token{}, ErrNoToken
+	}
+	return tok, nil
+}
+
+// Set implements SecretsStore.
+func (s *fileSecretsStore) Set(key string, tok Token) error {
+	tokens, err := s.load()
+	if err != nil {
+		return err
+	}
+	tokens[key] = tok
+
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(s.path), err)
+	}
+
+	return os.WriteFile(s.path, b, 0o600)
+}
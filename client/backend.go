@@ -0,0 +1,61 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var _ Backend = (*Client)(nil)
+
+// PullImage implements Backend, downloading the SIF image identified by path
+// (eg. "entity/collection/container:tag") from the Cloud-Library Service.
+func (c *Client) PullImage(ctx context.Context, w io.Writer, path string) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "v1/imagefile/"+path, "", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("download of %q failed with status %d", path, res.StatusCode)
+	}
+
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("error downloading image: %w", err)
+	}
+	return nil
+}
+
+// PushImage implements Backend, uploading the SIF image read from r (size
+// bytes) to path (eg. "entity/collection/container:tag") on the
+// Cloud-Library Service.
+func (c *Client) PushImage(ctx context.Context, r io.Reader, size int64, path string) error {
+	req, err := c.newRequest(ctx, http.MethodPut, "v1/imagefile/"+path, "", r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("upload to %q failed with status %d", path, res.StatusCode)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/go-log/log"
+	"github.com/vzokay/container-library-client/auth"
 )
 
 // Config contains the client configuration.
@@ -22,10 +23,27 @@ type Config struct {
 	BaseURL string
 	// Auth token to include in the Authorization header of each request (if supplied).
 	AuthToken string
+	// TokenSource, if supplied, is consulted for a bearer token on every
+	// request instead of the static AuthToken. Use this when the token
+	// must be obtained or refreshed transparently, such as via
+	// auth.DeviceFlow. TokenSource takes precedence over AuthToken.
+	TokenSource auth.TokenSource
 	// User agent to include in each request (if supplied).
 	UserAgent string
-	// HTTPClient to use to make HTTP requests (if supplied).
+	// HTTPClient to use to make HTTP requests (if supplied). Its
+	// Transport, if set, becomes the innermost layer of the middleware
+	// chain built from Middleware and TLSConfig; if unset,
+	// http.DefaultTransport is used.
 	HTTPClient *http.Client
+	// TLSConfig controls the TLS behavior of the client's transport. Its
+	// settings are ignored if HTTPClient.Transport is already a
+	// non-default, non-*http.Transport value.
+	TLSConfig *TLSConfig
+	// Middleware is a chain of http.RoundTripper decorators applied
+	// around the base transport, outermost first. See RetryMiddleware,
+	// RateLimitMiddleware, LoggingMiddleware, and MetricsMiddleware for
+	// built-in options.
+	Middleware []Middleware
 	// Logger to be used when output is generated
 	Logger log.Logger
 }
@@ -35,11 +53,12 @@ var DefaultConfig = &Config{}
 
 // Client describes the client details.
 type Client struct {
-	baseURL    *url.URL
-	authToken  string
-	userAgent  string
-	httpClient *http.Client
-	logger     log.Logger
+	baseURL     *url.URL
+	authToken   string
+	tokenSource auth.TokenSource
+	userAgent   string
+	httpClient  *http.Client
+	logger      log.Logger
 }
 
 const defaultBaseURL = ""
@@ -76,18 +95,52 @@ func NewClient(cfg *Config) (*Client, error) {
 	}
 
 	c := &Client{
-		baseURL:   baseURL,
-		authToken: cfg.AuthToken,
-		userAgent: cfg.UserAgent,
+		baseURL:     baseURL,
+		authToken:   cfg.AuthToken,
+		tokenSource: cfg.TokenSource,
+		userAgent:   cfg.UserAgent,
 	}
 
-	// Set HTTP client
-	if cfg.HTTPClient != nil {
-		c.httpClient = cfg.HTTPClient
+	// Set HTTP client, composing the effective transport from the base
+	// transport (either the caller's or one built from TLSConfig) and
+	// the configured middleware chain.
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
 	} else {
-		c.httpClient = http.DefaultClient
+		clone := *httpClient
+		httpClient = &clone
 	}
 
+	base := httpClient.Transport
+	if cfg.TLSConfig != nil {
+		// Only an *http.Transport (the caller's, or the default) has a
+		// TLSClientConfig to apply TLSConfig to; any other
+		// http.RoundTripper is left untouched, per Config.TLSConfig's
+		// doc comment.
+		var t *http.Transport
+		switch v := base.(type) {
+		case nil:
+			t = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			t = v.Clone()
+		}
+
+		if t != nil {
+			tlsConfig, err := cfg.TLSConfig.build()
+			if err != nil {
+				return nil, err
+			}
+			t.TLSClientConfig = tlsConfig
+			base = t
+		}
+	} else if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = chain(base, cfg.Middleware)
+
+	c.httpClient = httpClient
+
 	if cfg.Logger != nil {
 		c.logger = cfg.Logger
 	} else {
@@ -109,7 +162,15 @@ func (c *Client) newRequest(ctx context.Context, method, path, rawQuery string,
 		return nil, err
 	}
 
-	if v := c.authToken; v != "" {
+	if c.tokenSource != nil {
+		tok, err := c.tokenSource.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error obtaining token: %w", err)
+		}
+		if err := (bearerTokenCredentials{authToken: tok}).ModifyRequest(r); err != nil {
+			return nil, err
+		}
+	} else if v := c.authToken; v != "" {
 		if err := (bearerTokenCredentials{authToken: v}).ModifyRequest(r); err != nil {
 			return nil, err
 		}
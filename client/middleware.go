@@ -0,0 +1,29 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import "net/http"
+
+// Middleware wraps an http.RoundTripper with additional behavior, such as
+// retries, rate limiting, logging, or metrics collection.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// roundTripperFunc adapts a function to an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// chain composes middleware into a single http.RoundTripper wrapping base,
+// with middleware[0] being the outermost layer (the first to see a request
+// and the last to see its response).
+func chain(base http.RoundTripper, middleware []Middleware) http.RoundTripper {
+	rt := base
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt = middleware[i](rt)
+	}
+	return rt
+}
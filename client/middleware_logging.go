@@ -0,0 +1,34 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-log/log"
+)
+
+// LoggingMiddleware returns a Middleware that logs each request's method,
+// URL, and duration, along with its status code or error, via logger.
+func LoggingMiddleware(logger log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+
+			res, err := next.RoundTrip(r)
+
+			elapsed := time.Since(start)
+			if err != nil {
+				logger.Logf("%s %s: %v (%s)", r.Method, r.URL, err, elapsed)
+				return res, err
+			}
+
+			logger.Logf("%s %s: %d (%s)", r.Method, r.URL, res.StatusCode, elapsed)
+			return res, err
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// MetricsRecorder receives metrics about requests made through a Client's
+// transport. Implementations typically wire these calls to a Prometheus
+// registry, but the interface is deliberately metrics-system-agnostic.
+type MetricsRecorder interface {
+	// IncInFlight adjusts the number of in-flight requests by delta (+1
+	// when a request starts, -1 when it completes).
+	IncInFlight(delta int)
+	// ObserveRequest is called once per completed request with its
+	// method, resulting status code (0 if the request errored before a
+	// response was received), and duration.
+	ObserveRequest(method string, statusCode int, duration time.Duration)
+}
+
+// MetricsMiddleware returns a Middleware that reports request counts,
+// latencies, and in-flight counts to recorder.
+func MetricsMiddleware(recorder MetricsRecorder) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			recorder.IncInFlight(1)
+			defer recorder.IncInFlight(-1)
+
+			start := time.Now()
+			res, err := next.RoundTrip(r)
+			duration := time.Since(start)
+
+			statusCode := 0
+			if res != nil {
+				statusCode = res.StatusCode
+			}
+			recorder.ObserveRequest(r.Method, statusCode, duration)
+
+			return res, err
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a simple client-side token-bucket rate limiter.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	refill   float64 // tokens added per second
+	last     time.Time
+}
+
+func newTokenBucket(ratePerSecond float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		tokens:   float64(burst),
+		capacity: float64(burst),
+		refill:   ratePerSecond,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a token is available, or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.refill
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		deficit := 1 - b.tokens
+		wait := time.Duration(deficit / b.refill * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RateLimitMiddleware returns a Middleware that limits outgoing requests to
+// ratePerSecond, on average, allowing bursts of up to burst requests.
+func RateLimitMiddleware(ratePerSecond float64, burst int) Middleware {
+	b := newTokenBucket(ratePerSecond, burst)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if err := b.wait(r.Context()); err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(r)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryConfig controls the behavior of RetryMiddleware.
+type RetryConfig struct {
+	// MaxRetries is the maximum number of retries attempted after the
+	// initial request. Defaults to 3 if zero.
+	MaxRetries int
+	// BaseDelay is the upper bound of the delay before the first retry;
+	// each subsequent retry doubles the bound, and the actual delay is
+	// chosen at random below it (full jitter). Defaults to 500ms if zero.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed delay between retries. Defaults to 30s
+	// if zero.
+	MaxDelay time.Duration
+}
+
+// RetryMiddleware returns a Middleware that retries requests that fail with
+// a network error, a 5xx response, or a 429 response, using exponential
+// backoff with jitter. A Retry-After response header, if present, takes
+// precedence over the computed backoff.
+//
+// Only requests with a retryable body (GetBody is set, or Body is nil) are
+// retried, since the original body may have already been consumed.
+func RetryMiddleware(cfg RetryConfig) Middleware {
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	baseDelay := cfg.BaseDelay
+	if baseDelay == 0 {
+		baseDelay = 500 * time.Millisecond
+	}
+	maxDelay := cfg.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Body != nil && r.GetBody == nil {
+				return next.RoundTrip(r)
+			}
+
+			var res *http.Response
+			var err error
+
+			for attempt := 0; attempt <= maxRetries; attempt++ {
+				if attempt > 0 {
+					if r.GetBody != nil {
+						body, berr := r.GetBody()
+						if berr != nil {
+							return nil, berr
+						}
+						r.Body = body
+					}
+				}
+
+				res, err = next.RoundTrip(r)
+				if !shouldRetry(res, err) {
+					return res, err
+				}
+
+				if attempt == maxRetries {
+					break
+				}
+
+				delay := retryDelay(res, attempt, baseDelay, maxDelay)
+				if res != nil {
+					io.Copy(io.Discard, res.Body) //nolint:errcheck
+					res.Body.Close()
+				}
+
+				select {
+				case <-r.Context().Done():
+					return nil, r.Context().Err()
+				case <-time.After(delay):
+				}
+			}
+
+			return res, err
+		})
+	}
+}
+
+func shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// retryDelay computes the delay before the next attempt, honoring a
+// Retry-After header if present, and otherwise using exponential backoff
+// with full jitter.
+func retryDelay(res *http.Response, attempt int, base, max time.Duration) time.Duration {
+	if res != nil {
+		if ra := res.Header.Get("Retry-After"); ra != "" {
+			if secs, err := strconv.Atoi(ra); err == nil {
+				return time.Duration(secs) * time.Second
+			}
+			if t, err := http.ParseTime(ra); err == nil {
+				if d := time.Until(t); d > 0 {
+					return d
+				}
+				return 0
+			}
+		}
+	}
+
+	d := base << attempt
+	if d > max || d <= 0 {
+		d = max
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
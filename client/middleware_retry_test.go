@@ -0,0 +1,65 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		res  *http.Response
+		err  error
+		want bool
+	}{
+		{"network error", nil, errors.New("boom"), true},
+		{"429", &http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{"500", &http.Response{StatusCode: http.StatusInternalServerError}, nil, true},
+		{"503", &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, true},
+		{"200", &http.Response{StatusCode: http.StatusOK}, nil, false},
+		{"404", &http.Response{StatusCode: http.StatusNotFound}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRetry(tt.res, tt.err); got != tt.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelayHonorsRetryAfter(t *testing.T) {
+	res := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"2"}},
+	}
+
+	if got, want := retryDelay(res, 0, time.Second, time.Minute), 2*time.Second; got != want {
+		t.Errorf("retryDelay() = %v, want %v", got, want)
+	}
+}
+
+func TestRetryDelayBacksOffAndCaps(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := time.Second
+
+	for attempt := 0; attempt < 10; attempt++ {
+		bound := base << attempt
+		if bound > max {
+			bound = max
+		}
+
+		d := retryDelay(nil, attempt, base, max)
+		if d < 0 || d >= bound {
+			t.Errorf("retryDelay(attempt=%d) = %v, want in [0, %v)", attempt, d, bound)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	libraryRefScheme  = "library://"
+	registryRefScheme = "registry://"
+)
+
+// backendFor resolves ref to the Backend that should handle it, along with
+// the ref stripped of its scheme.
+func backendFor(ref string, lib *Client, oci *OCIClient) (Backend, string, error) {
+	switch {
+	case strings.HasPrefix(ref, libraryRefScheme):
+		if lib == nil {
+			return nil, "", fmt.Errorf("%q requires a library client", ref)
+		}
+		return lib, strings.TrimPrefix(ref, libraryRefScheme), nil
+	case strings.HasPrefix(ref, registryRefScheme):
+		if oci == nil {
+			return nil, "", fmt.Errorf("%q requires an OCI client", ref)
+		}
+		return oci, strings.TrimPrefix(ref, registryRefScheme), nil
+	default:
+		return nil, "", fmt.Errorf("ref %q must be prefixed with %q or %q", ref, libraryRefScheme, registryRefScheme)
+	}
+}
+
+// Mirror copies the SIF image identified by srcRef to dstRef, where each ref
+// is prefixed with "library://" or "registry://" to select which of lib and
+// oci handles it. This allows images to be mirrored between the
+// Cloud-Library Service and any OCI-compliant registry without the caller
+// needing to special-case either side.
+//
+// lib and oci may be nil if srcRef/dstRef never require them; supplying a
+// scheme without the corresponding client is an error.
+//
+// When both srcRef and dstRef resolve to OCIClient, and the destination
+// already holds a blob matching the source's digest, the blob is mounted
+// cross-repo (or skipped if already present) rather than re-downloaded.
+func Mirror(ctx context.Context, srcRef, dstRef string, lib *Client, oci *OCIClient) error {
+	src, srcPath, err := backendFor(srcRef, lib, oci)
+	if err != nil {
+		return err
+	}
+	dst, dstPath, err := backendFor(dstRef, lib, oci)
+	if err != nil {
+		return err
+	}
+
+	if srcOCI, ok := src.(*OCIClient); ok {
+		if dstOCI, ok := dst.(*OCIClient); ok {
+			return mirrorOCIToOCI(ctx, srcOCI, srcPath, dstOCI, dstPath)
+		}
+	}
+
+	pr, pw := io.Pipe()
+
+	pullErrCh := make(chan error, 1)
+	go func() {
+		pullErrCh <- src.PullImage(ctx, pw, srcPath)
+		pw.Close()
+	}()
+
+	if err := dst.PushImage(ctx, pr, 0, dstPath); err != nil {
+		pr.CloseWithError(err)
+		<-pullErrCh
+		return fmt.Errorf("error pushing to %q: %w", dstRef, err)
+	}
+
+	if err := <-pullErrCh; err != nil {
+		return fmt.Errorf("error pulling from %q: %w", srcRef, err)
+	}
+	return nil
+}
+
+// mirrorOCIToOCI copies an image between two registries, mounting the layer
+// blob cross-repo (or skipping it entirely if already present) instead of
+// downloading and re-uploading it when possible.
+func mirrorOCIToOCI(ctx context.Context, src *OCIClient, srcPath string, dst *OCIClient, dstPath string) error {
+	srcRepo, srcTag, err := splitOCIRef(srcPath)
+	if err != nil {
+		return err
+	}
+	dstRepo, dstTag, err := splitOCIRef(dstPath)
+	if err != nil {
+		return err
+	}
+
+	m, err := src.getManifest(ctx, srcRepo, srcTag)
+	if err != nil {
+		return fmt.Errorf("error reading source manifest: %w", err)
+	}
+
+	if src.baseURL.String() == dst.baseURL.String() {
+		// Same registry: every blob can be mounted cross-repo without
+		// ever leaving the server.
+		layers := make([]descriptor, len(m.Layers))
+		for i, l := range m.Layers {
+			d, err := dst.mountOrExists(ctx, dstRepo, srcRepo, l)
+			if err != nil {
+				return err
+			}
+			layers[i] = d
+		}
+		cfg, err := dst.mountOrExists(ctx, dstRepo, srcRepo, m.Config)
+		if err != nil {
+			return err
+		}
+		m.Layers = layers
+		m.Config = cfg
+		return dst.putManifest(ctx, dstRepo, dstTag, m)
+	}
+
+	// Different registries: fall back to a streaming copy, but skip any
+	// blob already present at the destination. The config blob is copied
+	// along with the layers, since the manifest references it too.
+	blobs := make([]*descriptor, 0, len(m.Layers)+1)
+	for i := range m.Layers {
+		blobs = append(blobs, &m.Layers[i])
+	}
+	blobs = append(blobs, &m.Config)
+
+	for _, b := range blobs {
+		l := *b
+		exists, err := dst.blobExists(ctx, dstRepo, l.Digest)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		pr, pw := io.Pipe()
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- src.getBlob(ctx, pw, srcRepo, l.Digest)
+			pw.Close()
+		}()
+
+		d, err := dst.pushBlob(ctx, dstRepo, pr, l.MediaType)
+		if err != nil {
+			return fmt.Errorf("error copying blob %s: %w", l.Digest, err)
+		}
+		if err := <-errCh; err != nil {
+			return fmt.Errorf("error reading blob %s: %w", l.Digest, err)
+		}
+		*b = d
+	}
+
+	return dst.putManifest(ctx, dstRepo, dstTag, m)
+}
+
+// mountOrExists returns d's descriptor unchanged after ensuring the blob is
+// present in dstRepo, either because it already exists, because it was
+// mounted cross-repo from srcRepo, or (when the registry declines the
+// mount) by streaming it from srcRepo through the upload session the
+// registry opened instead.
+func (c *OCIClient) mountOrExists(ctx context.Context, dstRepo, srcRepo string, d descriptor) (descriptor, error) {
+	exists, err := c.blobExists(ctx, dstRepo, d.Digest)
+	if err != nil {
+		return descriptor{}, err
+	}
+	if exists {
+		return d, nil
+	}
+
+	location, mounted, err := c.startUpload(ctx, dstRepo, srcRepo, d.Digest)
+	if err != nil {
+		return descriptor{}, fmt.Errorf("error mounting %s: %w", d.Digest, err)
+	}
+	if mounted {
+		return d, nil
+	}
+
+	// The registry declined the cross-repo mount and opened a fresh
+	// upload session instead; src and dst share a registry, so dst can
+	// read the blob directly out of srcRepo to complete it.
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.getBlob(ctx, pw, srcRepo, d.Digest)
+		pw.Close()
+	}()
+
+	location, _, err = c.uploadChunks(ctx, location, pr)
+	if err != nil {
+		return descriptor{}, fmt.Errorf("error uploading %s: %w", d.Digest, err)
+	}
+	if err := <-errCh; err != nil {
+		return descriptor{}, fmt.Errorf("error reading %s: %w", d.Digest, err)
+	}
+
+	if err := c.finalizeUpload(ctx, location, d.Digest); err != nil {
+		return descriptor{}, fmt.Errorf("error finalizing %s: %w", d.Digest, err)
+	}
+
+	return d, nil
+}
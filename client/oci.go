@@ -0,0 +1,133 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-log/log"
+)
+
+// Backend is implemented by clients capable of pushing and pulling SIF
+// images, so that callers can write code that works against either the
+// Cloud-Library Service or an OCI-compliant registry.
+type Backend interface {
+	// PushImage uploads the SIF image read from r to ref.
+	PushImage(ctx context.Context, r io.Reader, size int64, ref string) error
+	// PullImage writes the SIF image identified by ref to w.
+	PullImage(ctx context.Context, w io.Writer, ref string) error
+}
+
+// OCIConfig contains the configuration for an OCIClient.
+type OCIConfig struct {
+	// BaseURL of the registry, eg. "https://registry-1.docker.io".
+	BaseURL string
+	// Username/Password are used for HTTP Basic auth and as a fallback
+	// credential when exchanging a token with the Bearer challenge realm.
+	Username string
+	Password string
+	// UserAgent to include in each request (if supplied).
+	UserAgent string
+	// HTTPClient to use to make HTTP requests (if supplied).
+	HTTPClient *http.Client
+	// Logger to be used when output is generated.
+	Logger log.Logger
+}
+
+// OCIClient speaks the Docker Registry V2 / OCI Distribution Spec against a
+// single registry, allowing SIF images to be pushed to and pulled from any
+// OCI-compliant registry (Harbor, GHCR, ECR, GCR, Docker Hub, ...) using the
+// same Backend surface as the Cloud-Library Service client.
+type OCIClient struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	userAgent  string
+	httpClient *http.Client
+	logger     log.Logger
+
+	// tokenCacheMu guards tokenCache, which is read and written from
+	// concurrent calls made through the same OCIClient (eg. pushing
+	// multiple layers of one image in parallel).
+	tokenCacheMu sync.Mutex
+	tokenCache   map[string]string
+}
+
+var _ Backend = (*OCIClient)(nil)
+
+// NewOCIClient sets up a new OCI Distribution client for the registry
+// identified by cfg.BaseURL.
+func NewOCIClient(cfg *OCIConfig) (*OCIClient, error) {
+	if cfg == nil {
+		cfg = &OCIConfig{}
+	}
+
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("no BaseURL supplied")
+	}
+
+	bu := cfg.BaseURL
+	if !strings.HasSuffix(bu, "/") {
+		bu += "/"
+	}
+
+	baseURL, err := url.Parse(bu)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported protocol scheme %q", baseURL.Scheme)
+	}
+
+	c := &OCIClient{
+		baseURL:    baseURL,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		userAgent:  cfg.UserAgent,
+		tokenCache: map[string]string{},
+	}
+
+	if cfg.HTTPClient != nil {
+		c.httpClient = cfg.HTTPClient
+	} else {
+		c.httpClient = http.DefaultClient
+	}
+
+	if cfg.Logger != nil {
+		c.logger = cfg.Logger
+	} else {
+		c.logger = log.DefaultLogger
+	}
+
+	return c, nil
+}
+
+// newRequest returns a new Request given a method, relative path, rawQuery,
+// and (optional) body. The request carries no credentials; do adds them
+// when the request is sent.
+func (c *OCIClient) newRequest(ctx context.Context, method, path, rawQuery string, body io.Reader) (*http.Request, error) {
+	u := c.baseURL.ResolveReference(&url.URL{
+		Path:     path,
+		RawQuery: rawQuery,
+	})
+
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if v := c.userAgent; v != "" {
+		r.Header.Set("User-Agent", v)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// bearerChallenge holds the parameters of a `Www-Authenticate: Bearer ...`
+// challenge, as defined by the Docker Registry V2 token authentication
+// specification.
+type bearerChallenge struct {
+	realm   string
+	service string
+	scope   string
+}
+
+// parseBearerChallenge parses the value of a Www-Authenticate header of the
+// form:
+//
+//	Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo/bar:pull"
+func parseBearerChallenge(header string) (bearerChallenge, bool) {
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return bearerChallenge{}, false
+	}
+
+	// Rejoin pieces split at a comma inside a quoted value, such as
+	// scope="repository:foo/bar:pull,push".
+	var parts []string
+	for _, part := range strings.Split(strings.TrimPrefix(header, prefix), ",") {
+		if n := len(parts); n > 0 && strings.Count(parts[n-1], `"`)%2 == 1 {
+			parts[n-1] += "," + part
+			continue
+		}
+		parts = append(parts, part)
+	}
+
+	var bc bearerChallenge
+	for _, part := range parts {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v := strings.Trim(kv[1], `"`)
+		switch kv[0] {
+		case "realm":
+			bc.realm = v
+		case "service":
+			bc.service = v
+		case "scope":
+			bc.scope = v
+		}
+	}
+
+	if bc.realm == "" {
+		return bearerChallenge{}, false
+	}
+	return bc, true
+}
+
+// tokenResponse is the body returned by a registry token endpoint.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// fetchBearerToken exchanges bc for a bearer token, using Basic auth against
+// the realm if credentials are configured.
+func (c *OCIClient) fetchBearerToken(ctx context.Context, bc bearerChallenge) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bc.realm, nil)
+	if err != nil {
+		return "", err
+	}
+
+	q := req.URL.Query()
+	if bc.service != "" {
+		q.Set("service", bc.service)
+	}
+	if bc.scope != "" {
+		q.Set("scope", bc.scope)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %q failed with status %d", bc.realm, res.StatusCode)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&tr); err != nil {
+		return "", fmt.Errorf("error decoding token response: %w", err)
+	}
+
+	if tr.Token != "" {
+		return tr.Token, nil
+	}
+	return tr.AccessToken, nil
+}
+
+// repositoryFromPath extracts the repository name from the path of a
+// /v2/... request, so tokens can be cached and reused per-repository rather
+// than per-exact-path. Repository names may themselves contain slashes
+// (namespaces), so this looks for the first well-known sub-resource marker
+// rather than splitting on "/".
+func repositoryFromPath(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, "v2/")
+
+	for _, marker := range []string{"/blobs/uploads/", "/blobs/", "/manifests/", "/tags/list"} {
+		if i := strings.Index(path, marker); i != -1 {
+			return path[:i]
+		}
+	}
+	return path
+}
+
+// scopeForMethod returns the Docker Registry V2 token scope actions needed
+// for a request of the given method: "pull" for read-only requests (GET,
+// HEAD), "pull,push" for anything that can write. The distinction matters
+// for token caching, since a token issued for a HEAD (pull) is commonly
+// rejected with 403 when reused for a subsequent PATCH/POST/PUT against the
+// same repository.
+func scopeForMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "pull"
+	default:
+		return "pull,push"
+	}
+}
+
+// tokenCacheKey identifies a cached token by repository and scope, since a
+// token issued for one scope is not generally valid for another, even
+// against the same repository.
+func tokenCacheKey(repository, scope string) string {
+	return repository + " " + scope
+}
+
+// cachedToken returns the bearer token cached for key, if any.
+func (c *OCIClient) cachedToken(key string) (string, bool) {
+	c.tokenCacheMu.Lock()
+	defer c.tokenCacheMu.Unlock()
+	tok, ok := c.tokenCache[key]
+	return tok, ok
+}
+
+// cacheToken stores tok as the bearer token for key.
+func (c *OCIClient) cacheToken(key, tok string) {
+	c.tokenCacheMu.Lock()
+	defer c.tokenCacheMu.Unlock()
+	c.tokenCache[key] = tok
+}
+
+// do sends r, using a cached bearer token for its repository and scope if
+// there is one, or else Basic auth if credentials are configured. If the
+// registry responds with a 401 or 403 and a Bearer challenge, do fetches a
+// token and retries once with it; any other challenge is returned as an
+// error without a retry. A 403 is treated the same as a
+// 401 because registries commonly use it to mean "the presented token is
+// valid but lacks the scope this request needs" (eg. a pull-scoped token
+// reused against a push), which a fresh challenge resolves.
+func (c *OCIClient) do(ctx context.Context, r *http.Request) (*http.Response, error) {
+	repository := repositoryFromPath(r.URL.Path)
+	cacheKey := tokenCacheKey(repository, scopeForMethod(r.Method))
+
+	if tok, ok := c.cachedToken(cacheKey); ok {
+		r.Header.Set("Authorization", "Bearer "+tok)
+	} else if c.username != "" {
+		r.SetBasicAuth(c.username, c.password)
+	}
+
+	res, err := c.httpClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusUnauthorized && res.StatusCode != http.StatusForbidden {
+		return res, nil
+	}
+	res.Body.Close()
+
+	challenge := res.Header.Get("Www-Authenticate")
+	bc, ok := parseBearerChallenge(challenge)
+	if !ok {
+		// Basic auth was already attempted above; nothing more we can do.
+		return nil, fmt.Errorf("unauthorized: %s", challenge)
+	}
+
+	tok, err := c.fetchBearerToken(ctx, bc)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching bearer token: %w", err)
+	}
+	c.cacheToken(cacheKey, tok)
+
+	retry := r.Clone(ctx)
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	retry.Header.Set("Authorization", "Bearer "+tok)
+
+	return c.httpClient.Do(retry)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseBearerChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bearerChallenge
+		wantOK bool
+	}{
+		{
+			name:   "full",
+			header: `Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo/bar:pull"`,
+			want: bearerChallenge{
+				realm:   "https://auth.example.com/token",
+				service: "registry.example.com",
+				scope:   "repository:foo/bar:pull",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "no scope",
+			header: `Bearer realm="https://auth.example.com/token",service="registry.example.com"`,
+			want: bearerChallenge{
+				realm:   "https://auth.example.com/token",
+				service: "registry.example.com",
+			},
+			wantOK: true,
+		},
+		{
+			name:   "not bearer",
+			header: `Basic realm="registry"`,
+			wantOK: false,
+		},
+		{
+			name:   "missing realm",
+			header: `Bearer service="registry.example.com"`,
+			wantOK: false,
+		},
+		{
+			name:   "empty",
+			header: "",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseBearerChallenge(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v2/foo/bar/blobs/uploads/", "foo/bar"},
+		{"v2/foo/bar/blobs/sha256:abc", "foo/bar"},
+		{"/v2/foo/bar/manifests/latest", "foo/bar"},
+		{"/v2/foo/bar/tags/list", "foo/bar"},
+		{"/v2/foo/bar/", "foo/bar/"},
+	}
+
+	for _, tt := range tests {
+		if got := repositoryFromPath(tt.path); got != tt.want {
+			t.Errorf("repositoryFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestScopeForMethod(t *testing.T) {
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{http.MethodGet, "pull"},
+		{http.MethodHead, "pull"},
+		{http.MethodPost, "pull,push"},
+		{http.MethodPatch, "pull,push"},
+		{http.MethodPut, "pull,push"},
+	}
+
+	for _, tt := range tests {
+		if got := scopeForMethod(tt.method); got != tt.want {
+			t.Errorf("scopeForMethod(%q) = %q, want %q", tt.method, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// chunkSize is the size of each PATCH request made during a chunked blob
+// upload.
+const chunkSize = 16 * 1024 * 1024
+
+// splitOCIRef splits ref of the form "repository:tag" or "repository@digest"
+// into its repository and tag/digest components.
+func splitOCIRef(ref string) (repository, tagOrDigest string, err error) {
+	if i := strings.LastIndex(ref, "@"); i != -1 {
+		return ref[:i], ref[i+1:], nil
+	}
+	if i := strings.LastIndex(ref, ":"); i != -1 {
+		return ref[:i], ref[i+1:], nil
+	}
+	return "", "", fmt.Errorf("invalid reference %q: missing tag or digest", ref)
+}
+
+// blobExists checks whether a blob identified by digest already exists in
+// repository, using a HEAD request.
+func (c *OCIClient) blobExists(ctx context.Context, repository, digest string) (bool, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, fmt.Sprintf("v2/%s/blobs/%s", repository, digest), "", nil)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+
+	return res.StatusCode == http.StatusOK, nil
+}
+
+// getBlob downloads the blob identified by digest from repository to w.
+func (c *OCIClient) getBlob(ctx context.Context, w io.Writer, repository, digest string) error {
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("v2/%s/blobs/%s", repository, digest), "", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("blob GET for %s@%s failed with status %d", repository, digest, res.StatusCode)
+	}
+
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("error downloading blob: %w", err)
+	}
+	return nil
+}
+
+// startUpload initiates a blob upload session for repository, optionally
+// attempting a cross-repo mount of digest from fromRepository. It reports
+// mounted=true if the registry mounted the blob (no upload is needed), or
+// otherwise returns the upload location to PATCH/PUT to (mounted=false).
+func (c *OCIClient) startUpload(ctx context.Context, repository, fromRepository, digest string) (location string, mounted bool, err error) {
+	rawQuery := ""
+	if fromRepository != "" && digest != "" {
+		q := "mount=" + digest + "&from=" + fromRepository
+		rawQuery = q
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, fmt.Sprintf("v2/%s/blobs/uploads/", repository), rawQuery, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusCreated:
+		// Cross-repo mount succeeded; the blob is already present.
+		return "", true, nil
+	case http.StatusAccepted:
+		return res.Header.Get("Location"), false, nil
+	default:
+		return "", false, fmt.Errorf("blob upload initiation for %s failed with status %d", repository, res.StatusCode)
+	}
+}
+
+// pushBlob uploads the contents of r, streamed in fixed-size chunks rather
+// than buffered in memory, to repository, returning its descriptor. The
+// digest and size recorded in the descriptor are always derived from the
+// bytes actually read from r, never from a caller-supplied size, so they
+// can never disagree with each other.
+func (c *OCIClient) pushBlob(ctx context.Context, repository string, r io.Reader, mt string) (descriptor, error) {
+	location, _, err := c.startUpload(ctx, repository, "", "")
+	if err != nil {
+		return descriptor{}, err
+	}
+
+	h := sha256.New()
+
+	location, size, err := c.uploadChunks(ctx, location, io.TeeReader(r, h))
+	if err != nil {
+		return descriptor{}, fmt.Errorf("error uploading blob: %w", err)
+	}
+
+	digest := "sha256:" + hex.EncodeToString(h.Sum(nil))
+
+	if err := c.finalizeUpload(ctx, location, digest); err != nil {
+		return descriptor{}, fmt.Errorf("error finalizing blob upload: %w", err)
+	}
+
+	return descriptor{MediaType: mt, Digest: digest, Size: size}, nil
+}
+
+// uploadChunks reads r in chunkSize pieces, PATCHing each to location in
+// turn, and returns the Location header to use for the final PUT along with
+// the total number of bytes read.
+func (c *OCIClient) uploadChunks(ctx context.Context, location string, r io.Reader) (string, int64, error) {
+	buf := make([]byte, chunkSize)
+	var offset int64
+
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPatch, location, bytes.NewReader(buf[:n]))
+			if err != nil {
+				return "", 0, err
+			}
+			req.Header.Set("Content-Type", "application/octet-stream")
+			req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(n)-1))
+			req.ContentLength = int64(n)
+
+			res, err := c.do(ctx, req)
+			if err != nil {
+				return "", 0, err
+			}
+			res.Body.Close()
+
+			if res.StatusCode != http.StatusAccepted {
+				return "", 0, fmt.Errorf("chunk PATCH at offset %d failed with status %d", offset, res.StatusCode)
+			}
+
+			location = res.Header.Get("Location")
+			offset += int64(n)
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			return location, offset, nil
+		}
+		if readErr != nil {
+			return "", 0, readErr
+		}
+	}
+}
+
+// finalizeUpload completes a blob upload session at location by PUTting the
+// final, empty-bodied request with the digest of the complete blob.
+func (c *OCIClient) finalizeUpload(ctx context.Context, location, digest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location, nil)
+	if err != nil {
+		return err
+	}
+	q := req.URL.Query()
+	q.Set("digest", digest)
+	req.URL.RawQuery = q.Encode()
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("blob PUT failed with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// catalogResponse is the body of a GET /v2/_catalog response.
+type catalogResponse struct {
+	Repositories []string `json:"repositories"`
+}
+
+// tagsResponse is the body of a GET /v2/<name>/tags/list response.
+type tagsResponse struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// OCISearchResult describes a single repository:tag pair found by Search.
+type OCISearchResult struct {
+	Repository string
+	Tag        string
+}
+
+// Search finds repositories in the registry's catalog whose name contains
+// value, returning one OCISearchResult per tag of each matching repository.
+//
+// The Docker Registry V2 / OCI Distribution Spec has no native search
+// endpoint, so this is implemented by listing the catalog (see catalog) and
+// filtering client-side, then listing tags for each match.
+func (c *OCIClient) Search(ctx context.Context, value string) ([]OCISearchResult, error) {
+	repos, err := c.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []OCISearchResult
+	for _, repo := range repos {
+		if !strings.Contains(repo, value) {
+			continue
+		}
+
+		tags, err := c.tags(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			results = append(results, OCISearchResult{Repository: repo, Tag: tag})
+		}
+	}
+	return results, nil
+}
+
+// catalog returns the repositories listed in a single GET /v2/_catalog
+// response. If the registry paginates its catalog, only the first page is
+// returned; the Link header pointing at further pages is not followed.
+func (c *OCIClient) catalog(ctx context.Context) ([]string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "v2/_catalog", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("catalog GET failed with status %d", res.StatusCode)
+	}
+
+	var cr catalogResponse
+	if err := json.NewDecoder(res.Body).Decode(&cr); err != nil {
+		return nil, fmt.Errorf("error decoding catalog: %w", err)
+	}
+	return cr.Repositories, nil
+}
+
+// tags returns the list of tags for repository.
+func (c *OCIClient) tags(ctx context.Context, repository string) ([]string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("v2/%s/tags/list", repository), "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tags GET for %s failed with status %d", repository, res.StatusCode)
+	}
+
+	var tr tagsResponse
+	if err := json.NewDecoder(res.Body).Decode(&tr); err != nil {
+		return nil, fmt.Errorf("error decoding tags: %w", err)
+	}
+	return tr.Tags, nil
+}
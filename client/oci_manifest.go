@@ -0,0 +1,142 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	mediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeImageIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeImageLayer    = "application/vnd.oci.image.layer.v1.tar"
+	mediaTypeImageConfig   = "application/vnd.oci.image.config.v1+json"
+
+	// sifLayerMediaType identifies a SIF image stored as a single OCI
+	// layer blob, as used by the Singularity/Apptainer OCI-SIF tooling.
+	sifLayerMediaType = "application/vnd.sylabs.sif.layer.v1.sif"
+)
+
+// descriptor is a reference to a blob, by digest and size, as defined by the
+// OCI image spec.
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// manifest is a minimal OCI image manifest: a config blob plus a list of
+// layer blobs.
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+// getManifest fetches and decodes the manifest for ref (a tag or digest)
+// within repository.
+func (c *OCIClient) getManifest(ctx context.Context, repository, ref string) (manifest, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("v2/%s/manifests/%s", repository, ref), "", nil)
+	if err != nil {
+		return manifest{}, err
+	}
+	req.Header.Set("Accept", mediaTypeImageManifest+", "+mediaTypeImageIndex)
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return manifest{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return manifest{}, fmt.Errorf("manifest GET for %s:%s failed with status %d", repository, ref, res.StatusCode)
+	}
+
+	var m manifest
+	if err := json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return manifest{}, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	return m, nil
+}
+
+// putManifest pushes m as the manifest for ref within repository.
+func (c *OCIClient) putManifest(ctx context.Context, repository, ref string, m manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, fmt.Sprintf("v2/%s/manifests/%s", repository, ref), "", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(b))
+	req.Header.Set("Content-Type", m.MediaType)
+
+	res, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("manifest PUT for %s:%s failed with status %d", repository, ref, res.StatusCode)
+	}
+	return nil
+}
+
+// PullImage implements Backend. ref has the form "repository:tag" or
+// "repository@digest".
+func (c *OCIClient) PullImage(ctx context.Context, w io.Writer, ref string) error {
+	repository, tagOrDigest, err := splitOCIRef(ref)
+	if err != nil {
+		return err
+	}
+
+	m, err := c.getManifest(ctx, repository, tagOrDigest)
+	if err != nil {
+		return err
+	}
+
+	if len(m.Layers) != 1 {
+		return fmt.Errorf("expected exactly one layer in manifest for %s, got %d", ref, len(m.Layers))
+	}
+
+	return c.getBlob(ctx, w, repository, m.Layers[0].Digest)
+}
+
+// PushImage implements Backend. ref has the form "repository:tag".
+func (c *OCIClient) PushImage(ctx context.Context, r io.Reader, size int64, ref string) error {
+	repository, tag, err := splitOCIRef(ref)
+	if err != nil {
+		return err
+	}
+
+	layer, err := c.pushBlob(ctx, repository, r, sifLayerMediaType)
+	if err != nil {
+		return fmt.Errorf("error pushing layer: %w", err)
+	}
+
+	emptyConfig, err := c.pushBlob(ctx, repository, bytes.NewReader([]byte("{}")), mediaTypeImageConfig)
+	if err != nil {
+		return fmt.Errorf("error pushing config: %w", err)
+	}
+
+	m := manifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeImageManifest,
+		Config:        emptyConfig,
+		Layers:        []descriptor{layer},
+	}
+
+	return c.putManifest(ctx, repository, tag, m)
+}
@@ -40,6 +40,10 @@ var errQueryValueMustBeSpecified = errors.New("search query ('value') must be sp
 //
 // Note: if 'arch' and/or 'signed' are specified, the search is limited in
 // scope only to the "Image" collection.
+//
+// Search is retained for backwards compatibility; new code should prefer
+// SearchQuery, whose typed fields are validated at build time and which
+// supports paging over large result sets via SearchIterator.
 func (c *Client) Search(ctx context.Context, args map[string]string) (*SearchResults, error) {
 	// "value" is minimally required in "args"
 	value, ok := args["value"]
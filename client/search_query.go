@@ -0,0 +1,260 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind identifies the type of entry a search may match.
+type Kind int
+
+const (
+	KindEntity Kind = iota
+	KindCollection
+	KindContainer
+	KindImage
+)
+
+// String returns the query-string value used to represent k.
+func (k Kind) String() string {
+	switch k {
+	case KindEntity:
+		return "entity"
+	case KindCollection:
+		return "collection"
+	case KindContainer:
+		return "container"
+	case KindImage:
+		return "image"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+var (
+	// ErrValueTooShort is returned by SearchQuery.Validate when Value is
+	// shorter than the minimum of 3 characters.
+	ErrValueTooShort = errors.New("search query ('value') must be at least 3 characters")
+	// ErrKindsRestrictedToImage is returned by SearchQuery.Validate when
+	// Archs or Signed is set alongside a Kinds list that contains
+	// anything other than KindImage. The service restricts arch/signed
+	// searches to the Image kind, so such a query can never match.
+	ErrKindsRestrictedToImage = errors.New("arch and signed filters restrict the search to the Image kind")
+	// ErrInvalidKind is returned by SearchQuery.Validate when Kinds
+	// contains a value outside the defined Kind constants.
+	ErrInvalidKind = errors.New("invalid search kind")
+)
+
+// SearchQuery specifies the parameters of a library search, for use with
+// Client.SearchQuery. Unlike the map[string]string accepted by Search, its
+// fields are validated at build time via Validate.
+type SearchQuery struct {
+	// Value is matched against all kinds (or those listed in Kinds, if
+	// non-empty). Must be at least 3 characters.
+	Value string
+	// Archs, if non-empty, restricts results to images built for one of
+	// the listed architectures (eg. "amd64", "arm64"). Restricts the
+	// search to the Image kind.
+	Archs []string
+	// Signed, if non-nil, restricts results to images that are (or are
+	// not) signed. Restricts the search to the Image kind.
+	Signed *bool
+	// Kinds, if non-empty, restricts results to the listed kinds.
+	Kinds []Kind
+	// Limit caps the number of results returned per page. The service
+	// default is used if zero.
+	Limit int
+	// PageToken resumes a search from a previous SearchIterator page. Set
+	// automatically by SearchIterator; callers building a fresh query
+	// should leave this empty.
+	PageToken string
+}
+
+// Validate reports whether q is well-formed, returning a joined error
+// (suitable for inspection with errors.Is) describing every violated
+// constraint, or nil if q is valid.
+func (q SearchQuery) Validate() error {
+	var errs []error
+
+	if len(q.Value) < 3 {
+		errs = append(errs, ErrValueTooShort)
+	}
+
+	if len(q.Archs) > 0 || q.Signed != nil {
+		for _, k := range q.Kinds {
+			if k != KindImage {
+				errs = append(errs, ErrKindsRestrictedToImage)
+				break
+			}
+		}
+	}
+
+	for _, k := range q.Kinds {
+		if k < KindEntity || k > KindImage {
+			errs = append(errs, fmt.Errorf("%w: %d", ErrInvalidKind, k))
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// values renders q as the query-string parameters understood by the
+// service's v1/search endpoint.
+func (q SearchQuery) values() url.Values {
+	v := url.Values{}
+	v.Set("value", q.Value)
+
+	if len(q.Archs) > 0 {
+		v.Set("arch", strings.Join(q.Archs, ","))
+	}
+	if q.Signed != nil {
+		v.Set("signed", strconv.FormatBool(*q.Signed))
+	}
+	if len(q.Kinds) > 0 {
+		kinds := make([]string, len(q.Kinds))
+		for i, k := range q.Kinds {
+			kinds[i] = k.String()
+		}
+		v.Set("kind", strings.Join(kinds, ","))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.PageToken != "" {
+		v.Set("page_token", q.PageToken)
+	}
+
+	return v
+}
+
+// SearchQuery returns a SearchIterator over the results of q. No request is
+// made until the iterator's Next method is called.
+func (c *Client) SearchQuery(ctx context.Context, q SearchQuery) *SearchIterator {
+	return &SearchIterator{ctx: ctx, client: c, query: q}
+}
+
+// searchPage is the JSON envelope returned by the v1/search endpoint,
+// extended with pagination metadata.
+type searchPage struct {
+	Data          SearchResults `json:"data"`
+	NextPageToken string        `json:"next_page_token"`
+}
+
+// SearchIterator ranges over the (potentially large) result set of a
+// SearchQuery, fetching one page at a time so callers never need to hold
+// the full result set in memory.
+type SearchIterator struct {
+	ctx    context.Context
+	client *Client
+	query  SearchQuery
+
+	started bool
+	done    bool
+}
+
+// ErrIteratorDone is returned by SearchIterator.Next once the result set is
+// exhausted.
+var ErrIteratorDone = errors.New("no more pages")
+
+// Next fetches and returns the next page of results, or ErrIteratorDone once
+// the service reports no further pages.
+func (it *SearchIterator) Next() (*SearchResults, error) {
+	if it.done {
+		return nil, ErrIteratorDone
+	}
+
+	if !it.started {
+		if err := it.query.Validate(); err != nil {
+			return nil, err
+		}
+		it.started = true
+	}
+
+	resJSON, nextToken, err := it.client.apiSearch(it.ctx, it.query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var page searchPage
+	if err := json.Unmarshal(resJSON, &page); err != nil {
+		return nil, fmt.Errorf("error decoding results: %w", err)
+	}
+
+	if nextToken == "" {
+		nextToken = page.NextPageToken
+	}
+
+	if nextToken == "" {
+		it.done = true
+	} else {
+		it.query.PageToken = nextToken
+	}
+
+	return &page.Data, nil
+}
+
+// apiSearch issues a GET against v1/search with the given query-string
+// values, returning the raw response body and, if present, the cursor from
+// a RFC 8288 Link: rel="next" response header.
+func (c *Client) apiSearch(ctx context.Context, v url.Values) (body []byte, nextPageToken string, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "v1/search", v.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%w: search request failed with status %d", errBadRequest, res.StatusCode)
+	}
+
+	return b, linkNextPageToken(res.Header.Get("Link")), nil
+}
+
+// linkNextRE matches a single link-value of an RFC 8288 Link header: the
+// URI-Reference inside angle brackets, followed by its parameters. Matching
+// the whole link-value (rather than splitting the header on ",") avoids
+// mistaking a comma inside the URL's own query string for a link-value
+// separator.
+var linkNextRE = regexp.MustCompile(`<([^>]*)>\s*((?:;\s*[^;,]+)*)`)
+
+// linkNextPageToken extracts the page_token query parameter of the URL in
+// the link-value carrying rel="next" within an RFC 8288 Link header, or ""
+// if there is no such link.
+func linkNextPageToken(link string) string {
+	for _, m := range linkNextRE.FindAllStringSubmatch(link, -1) {
+		if !strings.Contains(m[2], `rel="next"`) {
+			continue
+		}
+
+		u, err := url.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		return u.Query().Get("page_token")
+	}
+	return ""
+}
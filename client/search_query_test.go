@@ -0,0 +1,107 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSearchQueryValidate(t *testing.T) {
+	signed := true
+
+	tests := []struct {
+		name    string
+		q       SearchQuery
+		wantErr error
+	}{
+		{
+			name: "valid",
+			q:    SearchQuery{Value: "alpine"},
+		},
+		{
+			name:    "value too short",
+			q:       SearchQuery{Value: "ab"},
+			wantErr: ErrValueTooShort,
+		},
+		{
+			name:    "archs restricted to image",
+			q:       SearchQuery{Value: "alpine", Archs: []string{"amd64"}, Kinds: []Kind{KindContainer}},
+			wantErr: ErrKindsRestrictedToImage,
+		},
+		{
+			name: "archs with image kind is fine",
+			q:    SearchQuery{Value: "alpine", Archs: []string{"amd64"}, Kinds: []Kind{KindImage}},
+		},
+		{
+			name:    "signed restricted to image",
+			q:       SearchQuery{Value: "alpine", Signed: &signed, Kinds: []Kind{KindEntity}},
+			wantErr: ErrKindsRestrictedToImage,
+		},
+		{
+			name:    "invalid kind",
+			q:       SearchQuery{Value: "alpine", Kinds: []Kind{Kind(99)}},
+			wantErr: ErrInvalidKind,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.q.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() = %v, want it to wrap %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLinkNextPageToken(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "next link",
+			link: `<https://host/v1/search?page_token=abc>; rel="next"`,
+			want: "abc",
+		},
+		{
+			name: "next link with comma in page token",
+			link: `<https://host/v1/search?page_token=abc,def>; rel="next"`,
+			want: "abc,def",
+		},
+		{
+			name: "multiple links, next present",
+			link: `<https://host/v1/search?page_token=prev>; rel="prev", <https://host/v1/search?page_token=next>; rel="next"`,
+			want: "next",
+		},
+		{
+			name: "no next link",
+			link: `<https://host/v1/search?page_token=prev>; rel="prev"`,
+			want: "",
+		},
+		{
+			name: "empty header",
+			link: "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkNextPageToken(tt.link); got != tt.want {
+				t.Errorf("linkNextPageToken(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
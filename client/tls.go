@@ -0,0 +1,67 @@
+// Copyright (c) 2023, Sylabs Inc. All rights reserved.
+// This software is licensed under a 3-clause BSD license. Please consult the
+// LICENSE.md file distributed with the sources of this project regarding your
+// rights to use or distribute this software.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig controls the TLS behavior of the transport used by a Client.
+type TLSConfig struct {
+	// InsecureSkipVerify disables verification of the server's
+	// certificate chain and host name. This should only be used for
+	// testing, as it makes the connection susceptible to
+	// man-in-the-middle attacks.
+	InsecureSkipVerify bool
+	// CACertPath, if non-empty, names a PEM file of CA certificates to
+	// use instead of the system trust store.
+	CACertPath string
+	// ClientCert and ClientKey, if both non-empty, name PEM files used
+	// to present a client certificate for mutual TLS.
+	ClientCert string
+	ClientKey  string
+	// MinTLSVersion sets the minimum acceptable TLS version, eg.
+	// tls.VersionTLS12. Defaults to the crypto/tls default if zero.
+	MinTLSVersion uint16
+}
+
+// build returns a *tls.Config reflecting c's settings.
+func (c *TLSConfig) build() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify, //nolint:gosec
+		MinVersion:         c.MinTLSVersion,
+	}
+
+	if c.CACertPath != "" {
+		pem, err := os.ReadFile(c.CACertPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", c.CACertPath, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CACertPath)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, fmt.Errorf("ClientCert and ClientKey must both be supplied")
+		}
+
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}